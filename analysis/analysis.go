@@ -0,0 +1,87 @@
+// Package analysis defines the representation of a static-analysis pass
+// over calc source, modeled on go/analysis. An Analyzer describes a named
+// check; Run drives a set of Analyzers over a parsed file, in dependency
+// order, feeding each one a Pass it can use to read the AST, consult the
+// results of the Analyzers it Requires, and report findings.
+package analysis
+
+import (
+	"fmt"
+
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+// An Analyzer describes a single analysis pass: its identity (Name, Doc),
+// the other Analyzers it depends on (Requires), and the function that
+// performs the analysis (Run).
+type Analyzer struct {
+	Name     string
+	Doc      string
+	Requires []*Analyzer
+	Run      func(*Pass) (interface{}, error)
+}
+
+// A Pass holds everything an Analyzer's Run function needs: the parsed
+// file to inspect, a sink for reporting findings, and the results
+// produced by the Analyzers it Requires.
+type Pass struct {
+	Analyzer *Analyzer
+	File     *ast.File
+
+	// ResultOf holds the Run result of every Analyzer this Pass's
+	// Analyzer transitively Requires, keyed by *Analyzer.
+	ResultOf map[*Analyzer]interface{}
+
+	fset *token.File
+}
+
+// Report records a finding at pos. It funnels into token.File.AddError,
+// so findings surface the same way evaluation errors do.
+func (p *Pass) Report(pos token.Pos, msg string) {
+	p.fset.AddError(pos, msg)
+}
+
+// Run executes analyzers over file, whose errors (if any) are reported
+// against fset, in an order that respects each Analyzer's Requires. It
+// returns an error if the Requires form a cycle, or if an Analyzer's Run
+// itself fails; findings reported via Pass.Report do not count as
+// failures and do not stop later Analyzers from running.
+func Run(fset *token.File, file *ast.File, analyzers ...*Analyzer) error {
+	results := make(map[*Analyzer]interface{})
+	done := make(map[*Analyzer]bool)
+	visiting := make(map[*Analyzer]bool)
+
+	var visit func(a *Analyzer) error
+	visit = func(a *Analyzer) error {
+		if done[a] {
+			return nil
+		}
+		if visiting[a] {
+			return fmt.Errorf("analysis: %s has a cyclic Requires", a.Name)
+		}
+		visiting[a] = true
+		for _, dep := range a.Requires {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visiting[a] = false
+
+		pass := &Pass{Analyzer: a, File: file, ResultOf: results, fset: fset}
+		res, err := a.Run(pass)
+		if err != nil {
+			return fmt.Errorf("analysis: %s: %w", a.Name, err)
+		}
+		results[a] = res
+		done[a] = true
+		return nil
+	}
+
+	for _, a := range analyzers {
+		if err := visit(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
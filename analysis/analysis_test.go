@@ -0,0 +1,73 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"misc/calc/analysis"
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+func noopAnalyzer(name string, order *[]string, requires ...*analysis.Analyzer) *analysis.Analyzer {
+	a := &analysis.Analyzer{Name: name, Requires: requires}
+	a.Run = func(p *analysis.Pass) (interface{}, error) {
+		*order = append(*order, name)
+		return nil, nil
+	}
+	return a
+}
+
+func TestRunOrdersByRequires(t *testing.T) {
+	var order []string
+	a := noopAnalyzer("a", &order)
+	b := noopAnalyzer("b", &order, a)
+	c := noopAnalyzer("c", &order, b)
+
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, &ast.File{}, c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a", "b", "c"}
+	if len(order) != len(want) {
+		t.Fatalf("ran %v, want %v", order, want)
+	}
+	for i, name := range want {
+		if order[i] != name {
+			t.Errorf("order[%d] = %q, want %q", i, order[i], name)
+		}
+	}
+}
+
+func TestRunDetectsCycle(t *testing.T) {
+	a := &analysis.Analyzer{Name: "a"}
+	b := &analysis.Analyzer{Name: "b", Requires: []*analysis.Analyzer{a}}
+	a.Requires = []*analysis.Analyzer{b}
+	noop := func(p *analysis.Pass) (interface{}, error) { return nil, nil }
+	a.Run, b.Run = noop, noop
+
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, &ast.File{}, a); err == nil {
+		t.Fatal("expected an error for a cyclic Requires chain, got nil")
+	}
+}
+
+func TestRunPropagatesResultOf(t *testing.T) {
+	a := &analysis.Analyzer{Name: "a"}
+	a.Run = func(p *analysis.Pass) (interface{}, error) { return "a's result", nil }
+
+	var seen interface{}
+	b := &analysis.Analyzer{Name: "b", Requires: []*analysis.Analyzer{a}}
+	b.Run = func(p *analysis.Pass) (interface{}, error) {
+		seen = p.ResultOf[a]
+		return nil, nil
+	}
+
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, &ast.File{}, b); err != nil {
+		t.Fatal(err)
+	}
+	if seen != "a's result" {
+		t.Errorf("ResultOf[a] = %v, want %q", seen, "a's result")
+	}
+}
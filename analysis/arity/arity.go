@@ -0,0 +1,84 @@
+// Package arity defines an Analyzer that reports builtins called with the
+// wrong number of arguments, e.g. `if` not given exactly 3 arguments, or a
+// comparison operator given fewer than 2.
+package arity
+
+import (
+	"fmt"
+
+	"misc/calc/analysis"
+	"misc/calc/ast"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "arity",
+	Doc:  "report builtins called with the wrong number of arguments",
+	Run:  run,
+}
+
+// rules maps a builtin name to a predicate over its argument count. define
+// is handled separately in run, since unlike these, its valid argument
+// count depends on which of its two forms is used.
+var rules = map[string]func(n int) bool{
+	"if":     func(n int) bool { return n == 3 },
+	"set":    func(n int) bool { return n == 2 },
+	"lambda": func(n int) bool { return n >= 2 },
+	"=":      atLeast(2),
+	"<":      atLeast(2),
+	"<=":     atLeast(2),
+	">":      atLeast(2),
+	">=":     atLeast(2),
+	"<>":     atLeast(2),
+}
+
+func atLeast(min int) func(int) bool {
+	return func(n int) bool { return n >= min }
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		expr, ok := n.(*ast.Expression)
+		if !ok || len(expr.Nodes) == 0 {
+			return true
+		}
+		// The parser scans the comparison symbols as ast.Operator, so both
+		// leaf kinds can name a builtin here.
+		var name string
+		switch head := expr.Nodes[0].(type) {
+		case *ast.Identifier:
+			name = head.Lit
+		case *ast.Operator:
+			name = string(head.Val)
+		default:
+			return true
+		}
+		argc := len(expr.Nodes) - 1
+		if name == "define" {
+			if !validDefineArity(expr.Nodes) {
+				pass.Report(expr.Pos(), fmt.Sprintf(
+					"define called with %d argument(s)", argc))
+			}
+		} else if valid, known := rules[name]; known && !valid(argc) {
+			pass.Report(expr.Pos(), fmt.Sprintf(
+				"%s called with %d argument(s)", name, argc))
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// validDefineArity mirrors eval.evalDefine's own arity check: `(define
+// name value)` takes exactly 2 arguments, but `(define (name arg...)
+// body...)` takes a signature plus one or more body expressions, the
+// same body-length variability lambda allows.
+func validDefineArity(nodes []ast.Node) bool {
+	if len(nodes) < 2 {
+		return false
+	}
+	switch nodes[1].(type) {
+	case *ast.Expression:
+		return len(nodes) >= 3
+	default:
+		return len(nodes) == 3
+	}
+}
@@ -0,0 +1,78 @@
+package arity_test
+
+import (
+	"testing"
+
+	"misc/calc/analysis"
+	"misc/calc/analysis/arity"
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+func TestArityFlagsWrongIfArgCount(t *testing.T) {
+	// (if 1 2)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "if"},
+			&ast.Number{Val: 1},
+			&ast.Number{Val: 2},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, arity.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 1 {
+		t.Errorf("got %d errors, want 1", fset.NumErrors())
+	}
+}
+
+// Regression test: (define (f x) (print x) (* x x)) is valid, fully
+// supported syntax - a parameterized define with a 2-expression body -
+// and must not be flagged just because it has 3 arguments.
+func TestArityAllowsParameterizedDefineWithMultiExpressionBody(t *testing.T) {
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "define"},
+			&ast.Expression{Nodes: []ast.Node{
+				&ast.Identifier{Lit: "f"},
+				&ast.Identifier{Lit: "x"},
+			}},
+			&ast.Expression{Nodes: []ast.Node{
+				&ast.Identifier{Lit: "print"},
+				&ast.Identifier{Lit: "x"},
+			}},
+			&ast.Expression{Nodes: []ast.Node{
+				&ast.Identifier{Lit: "*"},
+				&ast.Identifier{Lit: "x"},
+				&ast.Identifier{Lit: "x"},
+			}},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, arity.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 0 {
+		t.Errorf("got %d errors, want 0", fset.NumErrors())
+	}
+}
+
+func TestArityFlagsPlainDefineWithTooManyArgs(t *testing.T) {
+	// (define x 1 2) - a plain `(define name value)` given an extra arg.
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "define"},
+			&ast.Identifier{Lit: "x"},
+			&ast.Number{Val: 1},
+			&ast.Number{Val: 2},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, arity.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 1 {
+		t.Errorf("got %d errors, want 1", fset.NumErrors())
+	}
+}
@@ -0,0 +1,53 @@
+// Package divzero defines an Analyzer that reports a literal division or
+// modulo by zero, e.g. `(/ x 0)` or `(% x 0)`.
+package divzero
+
+import (
+	"misc/calc/analysis"
+	"misc/calc/ast"
+	"misc/calc/eval/value"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "divzero",
+	Doc:  "report literal division or modulo by zero",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		expr, ok := n.(*ast.Expression)
+		if !ok || len(expr.Nodes) <= 1 {
+			return true
+		}
+		// The parser scans / and % as ast.Operator, so both leaf kinds are
+		// accepted as the head.
+		var op string
+		switch head := expr.Nodes[0].(type) {
+		case *ast.Identifier:
+			op = head.Lit
+		case *ast.Operator:
+			op = string(head.Val)
+		}
+		if op != "/" && op != "%" {
+			return true
+		}
+		for _, arg := range expr.Nodes[2:] {
+			num, ok := arg.(*ast.Number)
+			if !ok {
+				continue
+			}
+			// Val is 0 for every non-integer literal, so Lit decides when
+			// it parses.
+			zero := num.Val == 0
+			if v, ok := value.MakeFromLiteral(num.Lit); ok {
+				zero = !value.IsTrue(v)
+			}
+			if zero {
+				pass.Report(num.Pos(), op+" by literal zero")
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
@@ -0,0 +1,46 @@
+package divzero_test
+
+import (
+	"testing"
+
+	"misc/calc/analysis"
+	"misc/calc/analysis/divzero"
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+func TestDivZeroFlagsLiteralZeroDivisor(t *testing.T) {
+	// (/ 1 0)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "/"},
+			&ast.Number{Val: 1},
+			&ast.Number{Val: 0},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, divzero.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 1 {
+		t.Errorf("got %d errors, want 1", fset.NumErrors())
+	}
+}
+
+func TestDivZeroIgnoresNonZeroDivisor(t *testing.T) {
+	// (/ 1 2)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "/"},
+			&ast.Number{Val: 1},
+			&ast.Number{Val: 2},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, divzero.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 0 {
+		t.Errorf("got %d errors, want 0", fset.NumErrors())
+	}
+}
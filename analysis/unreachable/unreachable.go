@@ -0,0 +1,46 @@
+// Package unreachable defines an Analyzer that reports `if` expressions
+// whose condition is a constant-foldable literal, making one of the two
+// branches dead code.
+package unreachable
+
+import (
+	"misc/calc/analysis"
+	"misc/calc/ast"
+	"misc/calc/eval/value"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "unreachable",
+	Doc:  "report if expressions with a constant-foldable condition",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		expr, ok := n.(*ast.Expression)
+		if !ok || len(expr.Nodes) != 4 {
+			return true
+		}
+		head, ok := expr.Nodes[0].(*ast.Identifier)
+		if !ok || head.Lit != "if" {
+			return true
+		}
+		num, ok := expr.Nodes[1].(*ast.Number)
+		if !ok {
+			return true
+		}
+		// Val is 0 for every non-integer literal, so Lit decides when it
+		// parses.
+		zero := num.Val == 0
+		if v, ok := value.MakeFromLiteral(num.Lit); ok {
+			zero = !value.IsTrue(v)
+		}
+		if zero {
+			pass.Report(expr.Nodes[2].Pos(), "unreachable: if condition is always false")
+		} else {
+			pass.Report(expr.Nodes[3].Pos(), "unreachable: if condition is always true")
+		}
+		return true
+	})
+	return nil, nil
+}
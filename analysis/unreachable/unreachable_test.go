@@ -0,0 +1,48 @@
+package unreachable_test
+
+import (
+	"testing"
+
+	"misc/calc/analysis"
+	"misc/calc/analysis/unreachable"
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+func TestUnreachableFlagsConstantCondition(t *testing.T) {
+	// (if 0 1 2)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "if"},
+			&ast.Number{Val: 0},
+			&ast.Number{Val: 1},
+			&ast.Number{Val: 2},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, unreachable.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 1 {
+		t.Errorf("got %d errors, want 1", fset.NumErrors())
+	}
+}
+
+func TestUnreachableIgnoresNonConstantCondition(t *testing.T) {
+	// (if x 1 2)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "if"},
+			&ast.Identifier{Lit: "x"},
+			&ast.Number{Val: 1},
+			&ast.Number{Val: 2},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, unreachable.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 0 {
+		t.Errorf("got %d errors, want 0", fset.NumErrors())
+	}
+}
@@ -0,0 +1,73 @@
+// Package unusedvar defines an Analyzer that reports identifiers bound by
+// define or set but never referenced anywhere in the file.
+package unusedvar
+
+import (
+	"fmt"
+
+	"misc/calc/analysis"
+	"misc/calc/ast"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "unusedvar",
+	Doc:  "report identifiers bound by define or set but never referenced",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	// declSites holds the identifier node each bound name is declared at,
+	// so the reference-collecting pass below can recognize and skip a
+	// declaration's own name instead of mistaking it for a use.
+	bound := map[string]ast.Node{}
+	declSites := map[*ast.Identifier]bool{}
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		expr, ok := n.(*ast.Expression)
+		if !ok || len(expr.Nodes) < 2 {
+			return true
+		}
+		head, ok := expr.Nodes[0].(*ast.Identifier)
+		if !ok || (head.Lit != "define" && head.Lit != "set") {
+			return true
+		}
+		id := boundName(expr.Nodes[1])
+		if id == nil {
+			return true
+		}
+		bound[id.Lit] = id
+		declSites[id] = true
+		return true
+	})
+
+	used := map[string]bool{}
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok && !declSites[id] {
+			used[id.Lit] = true
+		}
+		return true
+	})
+
+	for name, decl := range bound {
+		if !used[name] {
+			pass.Report(decl.Pos(), fmt.Sprintf("%s declared and not used", name))
+		}
+	}
+	return nil, nil
+}
+
+// boundName extracts the identifier define/set binds from its first
+// argument, which is either a bare identifier (`(define x ...)`) or a
+// function signature (`(define (f x) ...)`).
+func boundName(n ast.Node) *ast.Identifier {
+	switch sig := n.(type) {
+	case *ast.Identifier:
+		return sig
+	case *ast.Expression:
+		if len(sig.Nodes) > 0 {
+			if id, ok := sig.Nodes[0].(*ast.Identifier); ok {
+				return id
+			}
+		}
+	}
+	return nil
+}
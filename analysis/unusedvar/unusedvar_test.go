@@ -0,0 +1,50 @@
+package unusedvar_test
+
+import (
+	"testing"
+
+	"misc/calc/analysis"
+	"misc/calc/analysis/unusedvar"
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+func TestUnusedVarFlagsUnreferencedDefine(t *testing.T) {
+	// (define x 1)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "define"},
+			&ast.Identifier{Lit: "x"},
+			&ast.Number{Val: 1},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, unusedvar.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 1 {
+		t.Errorf("got %d errors, want 1", fset.NumErrors())
+	}
+}
+
+func TestUnusedVarIgnoresReferencedDefine(t *testing.T) {
+	// (define x 1) (print x)
+	file := &ast.File{Nodes: []ast.Node{
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "define"},
+			&ast.Identifier{Lit: "x"},
+			&ast.Number{Val: 1},
+		}},
+		&ast.Expression{Nodes: []ast.Node{
+			&ast.Identifier{Lit: "print"},
+			&ast.Identifier{Lit: "x"},
+		}},
+	}}
+	fset := token.NewFile("test", "")
+	if err := analysis.Run(fset, file, unusedvar.Analyzer); err != nil {
+		t.Fatal(err)
+	}
+	if fset.NumErrors() != 0 {
+		t.Errorf("got %d errors, want 0", fset.NumErrors())
+	}
+}
@@ -0,0 +1,75 @@
+// Package ast defines the calc abstract syntax tree: a program is a list
+// of top-level nodes, where a node is either a parenthesized Expression or
+// one of three leaves (Identifier, Number, Operator).
+package ast
+
+import "misc/calc/token"
+
+// A Node is any node in a calc AST.
+type Node interface {
+	Pos() token.Pos
+}
+
+// File is the root of a parsed program: a sequence of top-level nodes
+// evaluated in order.
+type File struct {
+	Nodes []Node
+}
+
+// Pos returns the position of the first node in f, or token.NoPos if f is
+// empty.
+func (f *File) Pos() token.Pos {
+	if len(f.Nodes) == 0 {
+		return token.NoPos
+	}
+	return f.Nodes[0].Pos()
+}
+
+// Expression is a parenthesized form `(a b c...)`: the calc analogue of a
+// function call, a special form (define, set, lambda, if), or an operator
+// application.
+type Expression struct {
+	LParen token.Pos
+	Nodes  []Node
+}
+
+// Pos returns the position of e's opening paren.
+func (e *Expression) Pos() token.Pos { return e.LParen }
+
+// Identifier is a bare name, either a reference to a bound value or the
+// name position in a define/set/lambda form.
+type Identifier struct {
+	NamePos token.Pos
+	Lit     string
+}
+
+// Pos returns the position of i.
+func (i *Identifier) Pos() token.Pos { return i.NamePos }
+
+// Number is a numeral literal. Lit holds the literal exactly as scanned -
+// an integer ("7"), a rational ("1/3") or a float ("2.5") - for
+// value.MakeFromLiteral to parse into the Value of the matching kind. Val
+// is a best-effort plain-integer reading of Lit. It is 0 for zero and for
+// every non-integer literal alike, so Val == 0 alone does not mean the
+// literal is zero; analyzers (e.g. divzero, unreachable) parse Lit first
+// and fall back to Val only when Lit does not parse.
+type Number struct {
+	ValPos token.Pos
+	Lit    string
+	Val    int
+}
+
+// Pos returns the position of n.
+func (n *Number) Pos() token.Pos { return n.ValPos }
+
+// Operator is a built-in operator symbol (+, -, *, /, %, and the
+// comparisons) in function position. It's scanned as its own leaf type,
+// distinct from Identifier, so eval can assume it always resolves to a
+// builtin.
+type Operator struct {
+	OpPos token.Pos
+	Val   []byte
+}
+
+// Pos returns the position of o.
+func (o *Operator) Pos() token.Pos { return o.OpPos }
@@ -0,0 +1,58 @@
+package ast
+
+import "testing"
+
+// program builds the AST for `(+ 1 (* 2 3))`.
+func program() *File {
+	return &File{
+		Nodes: []Node{
+			&Expression{
+				Nodes: []Node{
+					&Identifier{Lit: "+"},
+					&Number{Val: 1},
+					&Expression{
+						Nodes: []Node{
+							&Identifier{Lit: "*"},
+							&Number{Val: 2},
+							&Number{Val: 3},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWalkCountsNodes(t *testing.T) {
+	count := 0
+	Inspect(program(), func(n Node) bool {
+		if n != nil {
+			count++
+		}
+		return true
+	})
+	// file, outer expr, "+", 1, inner expr, "*", 2, 3
+	const want = 8
+	if count != want {
+		t.Errorf("counted %d nodes, want %d", count, want)
+	}
+}
+
+func TestInspectCollectsIdentifiers(t *testing.T) {
+	var idents []string
+	Inspect(program(), func(n Node) bool {
+		if id, ok := n.(*Identifier); ok {
+			idents = append(idents, id.Lit)
+		}
+		return true
+	})
+	want := []string{"+", "*"}
+	if len(idents) != len(want) {
+		t.Fatalf("got identifiers %v, want %v", idents, want)
+	}
+	for i, lit := range want {
+		if idents[i] != lit {
+			t.Errorf("identifier %d = %q, want %q", i, idents[i], lit)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Command calc-repl is an interactive shell for calc. It reads one
+// balanced expression at a time from stdin - so a function definition
+// typed across several lines is submitted as a single input - evaluates
+// it against a scope that persists for the life of the session, and
+// prints the result.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"misc/calc/eval"
+	"misc/calc/eval/value"
+)
+
+const prompt = "calc> "
+const continuePrompt = "  ... "
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".calc_history")
+}
+
+func main() {
+	scope := eval.RootScope()
+	histPath := historyPath()
+	history := loadHistory(histPath)
+
+	in := bufio.NewReader(os.Stdin)
+	var buf strings.Builder
+	depth := 0
+
+	fmt.Fprint(os.Stdout, prompt)
+	for {
+		line, err := in.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+
+		if depth == 0 && buf.Len() == 0 && strings.HasPrefix(trimmed, ":") {
+			runCommand(trimmed, scope, history)
+			fmt.Fprint(os.Stdout, prompt)
+			if err != nil {
+				break
+			}
+			continue
+		}
+
+		buf.WriteString(line)
+		depth += parenDepth(line)
+
+		if depth <= 0 {
+			src := buf.String()
+			buf.Reset()
+			depth = 0
+			if strings.TrimSpace(src) != "" {
+				history = append(history, strings.TrimRight(src, "\n"))
+				appendHistory(histPath, src)
+				res := eval.EvalFile("<stdin>", src, scope)
+				if s := eval.Format(res); s != "" {
+					fmt.Fprintln(os.Stdout, s)
+				}
+			}
+			fmt.Fprint(os.Stdout, prompt)
+		} else {
+			fmt.Fprint(os.Stdout, continuePrompt)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+}
+
+// parenDepth returns the net change in paren nesting contributed by line:
+// +1 per '(' and -1 per ')'.
+func parenDepth(line string) int {
+	depth := 0
+	for _, r := range line {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	return depth
+}
+
+func runCommand(cmd string, scope *eval.Scope, history []string) {
+	fields := strings.SplitN(cmd, " ", 2)
+	switch fields[0] {
+	case ":reset":
+		*scope = *eval.RootScope()
+	case ":history":
+		for _, h := range history {
+			fmt.Fprintln(os.Stdout, h)
+		}
+	case ":load":
+		if len(fields) != 2 {
+			fmt.Fprintln(os.Stderr, ":load requires a file name")
+			return
+		}
+		src, err := ioutil.ReadFile(strings.TrimSpace(fields[1]))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		res := eval.EvalFile(fields[1], string(src), scope)
+		if s := eval.Format(res); s != "" {
+			fmt.Fprintln(os.Stdout, s)
+		}
+	case ":type":
+		if len(fields) != 2 {
+			fmt.Fprintln(os.Stderr, ":type requires an expression")
+			return
+		}
+		res := eval.EvalFile("<stdin>", fields[1], scope)
+		if v, ok := res.(value.Value); ok {
+			fmt.Fprintln(os.Stdout, v.Kind())
+			return
+		}
+		fmt.Fprintln(os.Stdout, "unknown")
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command:", fields[0])
+	}
+}
+
+// The history file is one record per line, but an entry (e.g. a
+// multi-line define) can itself contain newlines, so each record is
+// escapeEntry'd before it's written and unescapeEntry'd after it's read,
+// keeping embedded newlines from fragmenting into bogus extra entries.
+
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = unescapeEntry(line)
+	}
+	return lines
+}
+
+func appendHistory(path, entry string) {
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, escapeEntry(strings.TrimRight(entry, "\n")))
+}
+
+// escapeEntry encodes a history entry as a single line, escaping
+// backslashes and newlines so multi-line input round-trips unambiguously.
+func escapeEntry(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// unescapeEntry reverses escapeEntry.
+func unescapeEntry(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+				continue
+			}
+			b.WriteRune(r)
+			continue
+		}
+		switch r {
+		case 'n':
+			b.WriteByte('\n')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	if escaped {
+		b.WriteByte('\\')
+	}
+	return b.String()
+}
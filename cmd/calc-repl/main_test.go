@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestEscapeUnescapeEntryRoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"(+ 1 2)",
+		"line one\nline two",
+		`a literal \ backslash`,
+		"a literal \\ backslash and\na newline, together",
+		"(define (f x)\n  (* x x))",
+	}
+	for _, entry := range cases {
+		got := unescapeEntry(escapeEntry(entry))
+		if got != entry {
+			t.Errorf("unescapeEntry(escapeEntry(%q)) = %q, want %q", entry, got, entry)
+		}
+	}
+}
@@ -3,43 +3,134 @@ package eval
 import (
 	"fmt"
 	"misc/calc/ast"
+	"misc/calc/eval/value"
 	"misc/calc/parser"
 	"misc/calc/token"
 )
 
 var builtins = map[string]func([]interface{}) interface{}{
-	"+":      funcAdd,
-	"-":      funcSub,
-	"*":      funcMul,
-	"/":      funcDiv,
-	"%":      funcMod,
-	"=":      funcEq,
-	"<":      funcLess,
-	"<=":     funcLessEq,
-	">":      funcGreater,
-	">=":     funcGreaterEq,
-	"<>":     funcNotEq,
-	"define": funcDefine,
-	"if":     funcIf,
-	"print":  funcPrint,
-	"set":    funcSet,
-}
-
-var variables = map[string]interface{}{}
-var functions = map[string]func([]interface{}) interface{}{}
+	"+":     funcAdd,
+	"-":     funcSub,
+	"*":     funcMul,
+	"/":     funcDiv,
+	"%":     funcMod,
+	"=":     funcEq,
+	"<":     funcLess,
+	"<=":    funcLessEq,
+	">":     funcGreater,
+	">=":    funcGreaterEq,
+	"<>":    funcNotEq,
+	"print": funcPrint,
+}
+
+// closure is the runtime representation of a user-defined function,
+// created by either define or lambda: a list of formal parameter names, a
+// body of one or more expressions evaluated in order, and the scope in
+// effect where the closure was created. Capturing that defining scope
+// (rather than, say, copying its bindings) is what makes the function a
+// closure, and is also what lets a recursive define see its own binding.
+type closure struct {
+	params []string
+	body   []ast.Node
+	scope  *Scope
+}
+
+func (c *closure) call(f *token.File, pos token.Pos, args []interface{}) interface{} {
+	if len(args) != len(c.params) {
+		f.AddError(pos, fmt.Sprintf("function expects %d argument(s), got %d", len(c.params), len(args)))
+		return nil
+	}
+	call := NewScope(c.scope)
+	for i, p := range c.params {
+		call.Insert(p, args[i])
+	}
+	var res interface{}
+	for _, n := range c.body {
+		res = eval(f, n, call)
+	}
+	return res
+}
+
+// identifierNames converts a list of AST nodes, each expected to be a bare
+// identifier, into their literal names. It's used to turn a parameter
+// list like (x y) into []string{"x", "y"}.
+func identifierNames(nodes []ast.Node) ([]string, bool) {
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		ident, ok := n.(*ast.Identifier)
+		if !ok {
+			return nil, false
+		}
+		names[i] = ident.Lit
+	}
+	return names, true
+}
+
+// A Scope maintains the set of named values (variables and user-defined
+// functions) visible at some point in a program, plus a link to the
+// immediately surrounding (outer) scope. It is modeled on go/ast's Scope,
+// though calc has no need to distinguish object kinds, so a scope simply
+// maps a name to whatever value is currently bound to it.
+type Scope struct {
+	Outer *Scope
+	table map[string]interface{}
+}
+
+// NewScope creates a new scope nested inside outer. outer may be nil, in
+// which case the returned scope has no parent.
+func NewScope(outer *Scope) *Scope {
+	return &Scope{Outer: outer, table: make(map[string]interface{})}
+}
+
+// Lookup returns the value bound to name in s, or in the nearest outer
+// scope that binds it. The second result reports whether any scope in the
+// chain binds name at all.
+func (s *Scope) Lookup(name string) (interface{}, bool) {
+	for sc := s; sc != nil; sc = sc.Outer {
+		if v, ok := sc.table[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Insert binds name to val in s, shadowing (without disturbing) any
+// binding for name in an outer scope.
+func (s *Scope) Insert(name string, val interface{}) {
+	s.table[name] = val
+}
+
+// RootScope returns a fresh scope containing the calc builtins, the way
+// top-level evaluation always starts out. Hosts that want to inject their
+// own bindings should start from RootScope, Insert into it, and pass the
+// result to EvalFile.
+func RootScope() *Scope {
+	s := NewScope(nil)
+	for name, fn := range builtins {
+		s.Insert(name, fn)
+	}
+	return s
+}
 
 func EvalExpr(expr string) interface{} {
-	return EvalFile("", expr)
+	return EvalFile("", expr, nil)
 }
 
-func EvalFile(fname, expr string) interface{} {
+// EvalFile parses and evaluates expr. If scope is nil, evaluation starts
+// from a fresh RootScope; otherwise scope is used as-is, which lets a
+// host pre-populate bindings (or keep evaluating into the same scope
+// across repeated calls, as a REPL does).
+func EvalFile(fname, expr string, scope *Scope) interface{} {
+	if scope == nil {
+		scope = RootScope()
+	}
 	f := token.NewFile(fname, expr)
 	n := parser.ParseFile(f, expr)
 	if f.NumErrors() > 0 {
 		f.PrintErrors()
 		return nil
 	}
-	res := eval(f, n)
+	res := eval(f, n, scope)
 	if f.NumErrors() > 0 {
 		f.PrintErrors()
 		return nil
@@ -47,12 +138,12 @@ func EvalFile(fname, expr string) interface{} {
 	return res
 }
 
-func eval(f *token.File, n ast.Node) interface{} {
+func eval(f *token.File, n ast.Node, scope *Scope) interface{} {
 	switch node := n.(type) {
 	case *ast.File:
 		var x interface{}
 		for _, n := range node.Nodes {
-			x = eval(f, n) // scoping seems like it should come into play here
+			x = eval(f, n, scope)
 			switch t := x.(type) {
 			case *ast.Identifier:
 				f.AddError(t.Pos(), "Unknown identifier: ", t.Lit)
@@ -61,19 +152,17 @@ func eval(f *token.File, n ast.Node) interface{} {
 		}
 		return x
 	case *ast.Identifier:
-		if fn, ok := builtins[node.Lit]; ok {
-			return fn
-		}
-		if fn, ok := functions[node.Lit]; ok {
-			//fmt.Println("found something for:", node.Lit)
-			return fn
-		}
-		if n, ok := variables[node.Lit]; ok {
-			return n
+		if v, ok := scope.Lookup(node.Lit); ok {
+			return v
 		}
 		return node
 	case *ast.Number:
-		return node.Val
+		v, ok := value.MakeFromLiteral(node.Lit)
+		if !ok {
+			f.AddError(node.Pos(), "invalid numeric literal: ", node.Lit)
+			return nil
+		}
+		return v
 	case *ast.Operator:
 		// technically, it should be impossible for this to fail. If it does,
 		// it should be a catistrophic error (like the panic that will be
@@ -87,8 +176,26 @@ func eval(f *token.File, n ast.Node) interface{} {
 			f.AddError(node.Pos(), "Empty expression not allowed")
 			return nil
 		}
-		fn, ok := eval(f, node.Nodes[0]).(func([]interface{}) interface{})
-		if !ok {
+		// define, set, lambda and if are special forms, not ordinary
+		// builtins: they need access to the current scope (define, set,
+		// lambda) or must not evaluate every argument up front (if), so
+		// they can't go through the usual evaluate-then-call path below.
+		if ident, ok := node.Nodes[0].(*ast.Identifier); ok {
+			switch ident.Lit {
+			case "define":
+				return evalDefine(f, node, scope)
+			case "set":
+				return evalSet(f, node, scope)
+			case "lambda":
+				return evalLambda(f, node, scope)
+			case "if":
+				return evalIf(f, node, scope)
+			}
+		}
+		fnVal := eval(f, node.Nodes[0], scope)
+		switch fnVal.(type) {
+		case func([]interface{}) interface{}, *closure:
+		default:
 			f.AddError(node.Nodes[0].Pos(), "First element of an expression must "+
 				"be a function.")
 			return nil
@@ -97,12 +204,18 @@ func eval(f *token.File, n ast.Node) interface{} {
 		args := make([]interface{}, 0) //len(node.Nodes[1:]))
 		if len(node.Nodes) > 1 {
 			for _, node := range node.Nodes[1:] {
-				args = append(args, eval(f, node))
+				args = append(args, eval(f, node, scope))
 			}
 		}
 		//fmt.Println("calling fn with", len(args), "args")
 
-		res := fn(args)
+		var res interface{}
+		switch fn := fnVal.(type) {
+		case func([]interface{}) interface{}:
+			res = fn(args)
+		case *closure:
+			res = fn.call(f, node.Pos(), args)
+		}
 		if err, ok := res.(error); ok {
 			f.AddError(node.Pos(), err)
 		}
@@ -112,137 +225,188 @@ func eval(f *token.File, n ast.Node) interface{} {
 	return nil
 }
 
-func funcAdd(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return a + b }, args)
-}
+func funcAdd(args []interface{}) interface{} { return genFunc("+", args) }
 
-func funcSub(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return a - b }, args)
-}
+func funcSub(args []interface{}) interface{} { return genFunc("-", args) }
 
-func funcMul(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return a * b }, args)
-}
+func funcMul(args []interface{}) interface{} { return genFunc("*", args) }
 
-func funcDiv(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return a / b }, args)
-}
+func funcDiv(args []interface{}) interface{} { return genFunc("/", args) }
 
-func funcMod(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return a % b }, args)
-}
+func funcMod(args []interface{}) interface{} { return genFunc("%", args) }
+
+func funcEq(args []interface{}) interface{} { return genFunc("=", args) }
+
+func funcLess(args []interface{}) interface{} { return genFunc("<", args) }
+
+func funcLessEq(args []interface{}) interface{} { return genFunc("<=", args) }
+
+func funcGreater(args []interface{}) interface{} { return genFunc(">", args) }
 
-func genFunc(fn func(a, b int) int, args []interface{}) interface{} {
+func funcGreaterEq(args []interface{}) interface{} { return genFunc(">=", args) }
+
+func funcNotEq(args []interface{}) interface{} { return genFunc("<>", args) }
+
+// genFunc folds op across args left to right, e.g. (- 10 1 2) computes
+// ((10 - 1) - 2). Every arg is expected to already be a value.Value
+// (numbers come in that way via ast.Number); for any other argument
+// genFunc returns nil without reporting an error. op is dispatched through value.BinaryOp, which takes care of
+// promoting operands along the numeric tower.
+func genFunc(op string, args []interface{}) interface{} {
 	if len(args) < 1 {
 		return nil
 	}
-	if len(args) < 2 {
-		if i, ok := args[0].(int); ok {
-			return i
-		}
+	res, ok := args[0].(value.Value)
+	if !ok {
+		// maybe return something like:
+		// errors.New("Function accepts numerical types only, got:", args[0])
 		return nil
 	}
-	var res int
-	if i, ok := args[0].(int); ok {
-		res = i
-	}
 	for _, x := range args[1:] {
-		switch v := x.(type) {
-		case int:
-			res = fn(res, v)
-		default:
-			// maybe return something like:
-			// errors.New("Function accepts numerical types only, got:", v)
+		v, ok := x.(value.Value)
+		if !ok {
 			return nil
 		}
+		r, err := value.BinaryOp(op, res, v)
+		if err != nil {
+			return err
+		}
+		res = r
 	}
 	return res
 }
 
-func funcEq(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return convBool(a == b) }, args)
-}
-
-func funcLess(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return convBool(a < b) }, args)
-}
-
-func funcLessEq(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return convBool(a <= b) }, args)
-}
-
-func funcGreater(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return convBool(a > b) }, args)
-}
-
-func funcGreaterEq(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return convBool(a >= b) }, args)
-}
-
-func funcNotEq(args []interface{}) interface{} {
-	return genFunc(func(a, b int) int { return convBool(a != b) }, args)
+// evalDefine implements the define special form, which comes in two
+// shapes:
+//
+//	(define name value)             bind name to a zero-arg function
+//	                                 that returns value's evaluation
+//	(define (name arg...) body...)  bind name to a closure over arg...
+//
+// In the first shape, binding through a callable (rather than storing the
+// value directly) keeps define distinct from set: a defined name must be
+// invoked, e.g. `(pi)`, while a set name evaluates to its value directly.
+// If value evaluates to a closure (typically a lambda), it's bound as-is.
+func evalDefine(f *token.File, node *ast.Expression, scope *Scope) interface{} {
+	if len(node.Nodes) < 3 {
+		f.AddError(node.Pos(), "define requires a name and a body")
+		return nil
+	}
+	switch sig := node.Nodes[1].(type) {
+	case *ast.Identifier:
+		if len(node.Nodes) != 3 {
+			f.AddError(node.Pos(), "define requires exactly 2 arguments")
+			return nil
+		}
+		r := eval(f, node.Nodes[2], scope)
+		if _, ok := node.Nodes[2].(*ast.Operator); ok {
+			f.AddError(node.Nodes[2].Pos(), "define cannot bind an operator")
+			return nil
+		}
+		if c, ok := r.(*closure); ok {
+			scope.Insert(sig.Lit, c)
+			return nil
+		}
+		scope.Insert(sig.Lit, func(args []interface{}) interface{} {
+			return r
+		})
+		return nil
+	case *ast.Expression:
+		if len(sig.Nodes) < 1 {
+			f.AddError(sig.Pos(), "define requires a function name")
+			return nil
+		}
+		name, ok := sig.Nodes[0].(*ast.Identifier)
+		if !ok {
+			f.AddError(sig.Nodes[0].Pos(), "define requires an identifier as the function name")
+			return nil
+		}
+		params, ok := identifierNames(sig.Nodes[1:])
+		if !ok {
+			f.AddError(sig.Pos(), "function parameters must be identifiers")
+			return nil
+		}
+		scope.Insert(name.Lit, &closure{params: params, body: node.Nodes[2:], scope: scope})
+		return nil
+	default:
+		f.AddError(node.Nodes[1].Pos(), "define requires an identifier or a function "+
+			"signature as its first argument")
+		return nil
+	}
 }
 
-func convBool(b bool) int {
-	if b {
-		return 1
+// evalLambda implements the `(lambda (arg...) body...)` special form,
+// producing an anonymous closure over the current scope. It's the
+// expression form that `(define (name arg...) body...)` sugars over.
+func evalLambda(f *token.File, node *ast.Expression, scope *Scope) interface{} {
+	if len(node.Nodes) < 3 {
+		f.AddError(node.Pos(), "lambda requires a parameter list and a body")
+		return nil
 	}
-	return 0
+	sig, ok := node.Nodes[1].(*ast.Expression)
+	if !ok {
+		f.AddError(node.Nodes[1].Pos(), "lambda requires a parameter list")
+		return nil
+	}
+	params, ok := identifierNames(sig.Nodes)
+	if !ok {
+		f.AddError(sig.Pos(), "lambda parameters must be identifiers")
+		return nil
+	}
+	return &closure{params: params, body: node.Nodes[2:], scope: scope}
 }
 
-func funcDefine(args []interface{}) interface{} {
-	//fmt.Println("define")
-	if len(args) != 2 {
-		return nil // really feel like this should be an error...not just nil
+// evalSet implements the `(set name value)` special form: unlike define,
+// it binds name directly to value in scope, so name subsequently
+// evaluates to value rather than needing to be invoked.
+func evalSet(f *token.File, node *ast.Expression, scope *Scope) interface{} {
+	if len(node.Nodes) != 3 {
+		f.AddError(node.Pos(), "set requires exactly 2 arguments")
+		return nil
 	}
-	if i, ok := args[0].(*ast.Identifier); ok {
-		switch args[1].(type) {
-		case *ast.Operator:
-			return nil // this REALLY should produce an error...
-		default:
-			//fmt.Println("adding", i.Lit, "to variables list:", args[1])
-			r := args[1]
-			functions[i.Lit] = func(args []interface{}) interface{} {
-				//fmt.Printf("executing function...%v\n", r)
-				return r
-			}
-		}
+	i, ok := node.Nodes[1].(*ast.Identifier)
+	if !ok {
+		f.AddError(node.Nodes[1].Pos(), "set requires an identifier as its first argument")
+		return nil
+	}
+	r := eval(f, node.Nodes[2], scope)
+	if _, ok := node.Nodes[2].(*ast.Operator); ok {
+		f.AddError(node.Nodes[2].Pos(), "set cannot bind an operator")
+		return nil
 	}
+	scope.Insert(i.Lit, r)
 	return nil
 }
 
-func funcIf(args []interface{}) interface{} {
-	if len(args) != 3 {
-		return nil //should produce error
+// evalIf implements the if special form. Unlike an ordinary builtin it
+// must not evaluate both branches eagerly: that would make a recursive
+// function like `(define (fact n) (if (<= n 1) 1 (* n (fact (- n 1)))))`
+// recurse forever, since the recursive branch would always be evaluated
+// regardless of the condition.
+func evalIf(f *token.File, node *ast.Expression, scope *Scope) interface{} {
+	if len(node.Nodes) != 4 {
+		f.AddError(node.Pos(), "if requires exactly 3 arguments")
+		return nil
 	}
-	if eq, ok := args[0].(int); ok {
-		if eq == 0 {
-			return args[2]
-		}
-		return args[1]
+	if truthy(eval(f, node.Nodes[1], scope)) {
+		return eval(f, node.Nodes[2], scope)
 	}
-	return nil // also an error
+	return eval(f, node.Nodes[3], scope)
 }
 
-func funcPrint(args []interface{}) interface{} {
-	// some checks should be done on the args. For example, this current
-	// implementation will return the address of a built-in function if
-	// given as an argument.
-	fmt.Println(args...)
-	return nil
+// truthy reports whether v should be treated as true by if.
+func truthy(v interface{}) bool {
+	if val, ok := v.(value.Value); ok {
+		return value.IsTrue(val)
+	}
+	return v != nil
 }
 
-func funcSet(args []interface{}) interface{} {
-	if len(args) != 2 {
-		return nil // really feel like this should be an error...not just nil
-	}
-	if i, ok := args[0].(*ast.Identifier); ok {
-		switch args[1].(type) {
-		case *ast.Operator:
-			return nil // this REALLY should produce an error...
-		default:
-			variables[i.Lit] = args[1]
-		}
+func funcPrint(args []interface{}) interface{} {
+	rendered := make([]interface{}, len(args))
+	for i, a := range args {
+		rendered[i] = Format(a)
 	}
+	fmt.Println(rendered...)
 	return nil
 }
@@ -0,0 +1,61 @@
+package eval
+
+import (
+	"testing"
+
+	"misc/calc/eval/value"
+)
+
+func TestScopeLookupWalksOuterChain(t *testing.T) {
+	root := NewScope(nil)
+	root.Insert("x", 1)
+	child := NewScope(root)
+
+	v, ok := child.Lookup("x")
+	if !ok || v != 1 {
+		t.Errorf("child.Lookup(%q) = %v, %v, want 1, true", "x", v, ok)
+	}
+}
+
+func TestScopeInsertShadowsOuterWithoutDisturbingIt(t *testing.T) {
+	root := NewScope(nil)
+	root.Insert("x", 1)
+	child := NewScope(root)
+	child.Insert("x", 2)
+
+	if v, _ := child.Lookup("x"); v != 2 {
+		t.Errorf("child.Lookup(%q) = %v, want 2", "x", v)
+	}
+	if v, _ := root.Lookup("x"); v != 1 {
+		t.Errorf("root.Lookup(%q) = %v, want 1 (shadowing must not mutate the outer scope)", "x", v)
+	}
+}
+
+func TestScopeLookupMissReportsNotFound(t *testing.T) {
+	root := NewScope(nil)
+	if _, ok := root.Lookup("missing"); ok {
+		t.Errorf("Lookup(%q) reported found, want not found", "missing")
+	}
+}
+
+func TestClosureBindsParametersAndEvaluatesBodyInOrder(t *testing.T) {
+	scope := RootScope()
+	EvalFile("", "(define (square x) (* x x))", scope)
+
+	res := EvalFile("", "(square 5)", scope)
+	v, ok := res.(value.Value)
+	if !ok || v.String() != "25" {
+		t.Fatalf("(square 5) = %v, want 25", res)
+	}
+}
+
+func TestRecursiveClosureSeesItsOwnBinding(t *testing.T) {
+	scope := RootScope()
+	EvalFile("", "(define (fact n) (if (<= n 1) 1 (* n (fact (- n 1)))))", scope)
+
+	res := EvalFile("", "(fact 5)", scope)
+	v, ok := res.(value.Value)
+	if !ok || v.String() != "120" {
+		t.Fatalf("(fact 5) = %v, want 120", res)
+	}
+}
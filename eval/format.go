@@ -0,0 +1,28 @@
+package eval
+
+import (
+	"fmt"
+
+	"misc/calc/eval/value"
+)
+
+// Format renders a result produced by EvalFile/EvalExpr the way a human
+// should see it: numeric tower values and booleans print via their own
+// String method, functions print as a distinguishable placeholder rather
+// than a raw pointer (as fmt.Println(args...) would in funcPrint), and
+// nil - the result of a statement with no useful value, like define or
+// set - renders as the empty string.
+func Format(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case value.Value:
+		return t.String()
+	case *closure:
+		return fmt.Sprintf("<function(%d args)>", len(t.params))
+	case func([]interface{}) interface{}:
+		return "<builtin>"
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
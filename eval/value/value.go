@@ -0,0 +1,291 @@
+// Package value implements calc's numeric tower, modeled on go/constant:
+// a sealed Value interface with one implementation per kind, arithmetic
+// that promotes operands the way Go's untyped constants do (Int -> Rat ->
+// Float), and constructors for building values from literals or from Go's
+// native numeric types.
+package value
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Kind describes which concrete representation a Value holds. Kinds are
+// ordered: when two Values of different Kind meet in a binary operation,
+// the lower one is promoted to the higher.
+type Kind int
+
+const (
+	Bool Kind = iota
+	Int
+	Rat
+	Float
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Bool:
+		return "bool"
+	case Int:
+		return "int"
+	case Rat:
+		return "rat"
+	case Float:
+		return "float"
+	}
+	return "invalid"
+}
+
+// A Value is an arbitrary-precision calc runtime value. It is a sealed
+// interface: the only implementations are BoolVal, IntVal, RatVal and
+// FloatVal.
+type Value interface {
+	Kind() Kind
+	String() string
+
+	// sealed prevents types outside this package from implementing Value.
+	sealed()
+}
+
+// BoolVal is the result of a comparison, and the value calc's if inspects.
+type BoolVal bool
+
+func (b BoolVal) Kind() Kind    { return Bool }
+func (b BoolVal) String() string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+func (BoolVal) sealed() {}
+
+// MakeBool returns b as a Value.
+func MakeBool(b bool) Value { return BoolVal(b) }
+
+// IntVal is an arbitrary-precision integer. Because it's backed by
+// *big.Int, arithmetic on it never overflows the way fixed-width int
+// arithmetic would - it promotes to more digits instead of wrapping.
+type IntVal struct{ val *big.Int }
+
+func (v IntVal) Kind() Kind     { return Int }
+func (v IntVal) String() string { return v.val.String() }
+func (IntVal) sealed()          {}
+
+// MakeInt returns x as a Value.
+func MakeInt(x *big.Int) Value { return IntVal{val: x} }
+
+// MakeInt64 returns x as a Value.
+func MakeInt64(x int64) Value { return IntVal{val: big.NewInt(x)} }
+
+// RatVal is an arbitrary-precision rational number, used for exact
+// division of integers (e.g. (/ 1 3)) rather than truncating to 0.
+type RatVal struct{ val *big.Rat }
+
+func (v RatVal) Kind() Kind     { return Rat }
+func (v RatVal) String() string { return v.val.RatString() }
+func (RatVal) sealed()          {}
+
+// MakeRat returns x as a Value.
+func MakeRat(x *big.Rat) Value { return RatVal{val: x} }
+
+// FloatVal is an arbitrary-precision floating-point number, produced
+// whenever a Float operand is mixed into an expression (e.g. (+ 1 2.5)).
+type FloatVal struct{ val *big.Float }
+
+func (v FloatVal) Kind() Kind     { return Float }
+func (v FloatVal) String() string { return v.val.Text('g', 10) }
+func (FloatVal) sealed()          {}
+
+// MakeFloat returns x as a Value.
+func MakeFloat(x *big.Float) Value { return FloatVal{val: x} }
+
+// MakeFromLiteral parses lit, a scanner-recognized numeral (an integer
+// such as "7", a rational such as "1/3", or a float such as "2.5"), into
+// the Value of the corresponding kind. It reports ok=false if lit isn't a
+// valid numeral. eval's *ast.Number case calls this on every literal it
+// evaluates. It tries an integer reading first, then a float reading for
+// literals holding '.', 'e' or 'E', and a rational reading last.
+func MakeFromLiteral(lit string) (v Value, ok bool) {
+	if i, success := new(big.Int).SetString(lit, 10); success {
+		return IntVal{val: i}, true
+	}
+	// big.Rat.SetString also accepts decimal notation (e.g. "2.5"), so a
+	// float literal must be recognized before it's given the chance to be
+	// misread as an exact rational.
+	if strings.ContainsAny(lit, ".eE") {
+		if fl, _, err := big.ParseFloat(lit, 10, 0, big.ToNearestEven); err == nil {
+			return FloatVal{val: fl}, true
+		}
+		return nil, false
+	}
+	if r, success := new(big.Rat).SetString(lit); success {
+		return RatVal{val: r}, true
+	}
+	return nil, false
+}
+
+// IsTrue reports whether v should be treated as true by calc's if: every
+// Value is true except BoolVal(false) and a numeric zero.
+func IsTrue(v Value) bool {
+	switch t := v.(type) {
+	case BoolVal:
+		return bool(t)
+	case IntVal:
+		return t.val.Sign() != 0
+	case RatVal:
+		return t.val.Sign() != 0
+	case FloatVal:
+		return t.val.Sign() != 0
+	}
+	return false
+}
+
+// asNumeric coerces a BoolVal operand to the Int 0/1 it stands for so
+// that it can take part in promotion and arithmetic, the way a bool
+// result threaded back through a chained comparison used to behave when
+// every calc number was a plain Go int.
+func asNumeric(v Value) Value {
+	if b, ok := v.(BoolVal); ok {
+		if b {
+			return IntVal{val: big.NewInt(1)}
+		}
+		return IntVal{val: big.NewInt(0)}
+	}
+	return v
+}
+
+func raiseTo(v Value, k Kind) Value {
+	if v.Kind() == k {
+		return v
+	}
+	switch k {
+	case Rat:
+		switch t := v.(type) {
+		case IntVal:
+			return RatVal{val: new(big.Rat).SetInt(t.val)}
+		}
+	case Float:
+		switch t := v.(type) {
+		case IntVal:
+			return FloatVal{val: new(big.Float).SetInt(t.val)}
+		case RatVal:
+			f := new(big.Float).SetPrec(uint(t.val.Num().BitLen() + t.val.Denom().BitLen() + 64))
+			f.SetRat(t.val)
+			return FloatVal{val: f}
+		}
+	}
+	return v
+}
+
+// BinaryOp evaluates x op y, promoting x and y to their common Kind first
+// (Int -> Rat -> Float, exactly like Go's untyped constant arithmetic).
+// Division is special-cased: dividing two Ints promotes to Rat rather
+// than truncating, since big.Rat can represent the result exactly.
+func BinaryOp(op string, x, y Value) (Value, error) {
+	x, y = asNumeric(x), asNumeric(y)
+	k := x.Kind()
+	if y.Kind() > k {
+		k = y.Kind()
+	}
+	if op == "/" && k == Int {
+		k = Rat
+	}
+	x, y = raiseTo(x, k), raiseTo(y, k)
+	switch k {
+	case Int:
+		return intOp(op, x.(IntVal), y.(IntVal))
+	case Rat:
+		return ratOp(op, x.(RatVal), y.(RatVal))
+	case Float:
+		return floatOp(op, x.(FloatVal), y.(FloatVal))
+	}
+	return nil, fmt.Errorf("value: unsupported operand kind %s", k)
+}
+
+func intOp(op string, x, y IntVal) (Value, error) {
+	switch op {
+	case "+":
+		return IntVal{val: new(big.Int).Add(x.val, y.val)}, nil
+	case "-":
+		return IntVal{val: new(big.Int).Sub(x.val, y.val)}, nil
+	case "*":
+		return IntVal{val: new(big.Int).Mul(x.val, y.val)}, nil
+	case "%":
+		if y.val.Sign() == 0 {
+			return nil, fmt.Errorf("value: modulo by zero")
+		}
+		return IntVal{val: new(big.Int).Rem(x.val, y.val)}, nil
+	case "=":
+		return MakeBool(x.val.Cmp(y.val) == 0), nil
+	case "<":
+		return MakeBool(x.val.Cmp(y.val) < 0), nil
+	case "<=":
+		return MakeBool(x.val.Cmp(y.val) <= 0), nil
+	case ">":
+		return MakeBool(x.val.Cmp(y.val) > 0), nil
+	case ">=":
+		return MakeBool(x.val.Cmp(y.val) >= 0), nil
+	case "<>":
+		return MakeBool(x.val.Cmp(y.val) != 0), nil
+	}
+	return nil, fmt.Errorf("value: unsupported int operator %q", op)
+}
+
+func ratOp(op string, x, y RatVal) (Value, error) {
+	switch op {
+	case "+":
+		return RatVal{val: new(big.Rat).Add(x.val, y.val)}, nil
+	case "-":
+		return RatVal{val: new(big.Rat).Sub(x.val, y.val)}, nil
+	case "*":
+		return RatVal{val: new(big.Rat).Mul(x.val, y.val)}, nil
+	case "/":
+		if y.val.Sign() == 0 {
+			return nil, fmt.Errorf("value: division by zero")
+		}
+		return RatVal{val: new(big.Rat).Quo(x.val, y.val)}, nil
+	case "=":
+		return MakeBool(x.val.Cmp(y.val) == 0), nil
+	case "<":
+		return MakeBool(x.val.Cmp(y.val) < 0), nil
+	case "<=":
+		return MakeBool(x.val.Cmp(y.val) <= 0), nil
+	case ">":
+		return MakeBool(x.val.Cmp(y.val) > 0), nil
+	case ">=":
+		return MakeBool(x.val.Cmp(y.val) >= 0), nil
+	case "<>":
+		return MakeBool(x.val.Cmp(y.val) != 0), nil
+	}
+	return nil, fmt.Errorf("value: unsupported rational operator %q", op)
+}
+
+func floatOp(op string, x, y FloatVal) (Value, error) {
+	switch op {
+	case "+":
+		return FloatVal{val: new(big.Float).Add(x.val, y.val)}, nil
+	case "-":
+		return FloatVal{val: new(big.Float).Sub(x.val, y.val)}, nil
+	case "*":
+		return FloatVal{val: new(big.Float).Mul(x.val, y.val)}, nil
+	case "/":
+		if y.val.Sign() == 0 {
+			return nil, fmt.Errorf("value: division by zero")
+		}
+		return FloatVal{val: new(big.Float).Quo(x.val, y.val)}, nil
+	case "=":
+		return MakeBool(x.val.Cmp(y.val) == 0), nil
+	case "<":
+		return MakeBool(x.val.Cmp(y.val) < 0), nil
+	case "<=":
+		return MakeBool(x.val.Cmp(y.val) <= 0), nil
+	case ">":
+		return MakeBool(x.val.Cmp(y.val) > 0), nil
+	case ">=":
+		return MakeBool(x.val.Cmp(y.val) >= 0), nil
+	case "<>":
+		return MakeBool(x.val.Cmp(y.val) != 0), nil
+	}
+	return nil, fmt.Errorf("value: unsupported float operator %q", op)
+}
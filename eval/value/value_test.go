@@ -0,0 +1,84 @@
+package value
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestMakeFromLiteral(t *testing.T) {
+	cases := []struct {
+		lit  string
+		kind Kind
+	}{
+		{"7", Int},
+		{"1/3", Rat},
+		{"2.5", Float},
+	}
+	for _, c := range cases {
+		v, ok := MakeFromLiteral(c.lit)
+		if !ok {
+			t.Errorf("MakeFromLiteral(%q) reported ok=false", c.lit)
+			continue
+		}
+		if v.Kind() != c.kind {
+			t.Errorf("MakeFromLiteral(%q).Kind() = %v, want %v", c.lit, v.Kind(), c.kind)
+		}
+	}
+}
+
+func TestMakeFromLiteralRejectsNonNumeral(t *testing.T) {
+	if _, ok := MakeFromLiteral("not-a-number"); ok {
+		t.Error("MakeFromLiteral(\"not-a-number\") reported ok=true")
+	}
+}
+
+func TestBinaryOpPromotesIntAndFloatToFloat(t *testing.T) {
+	x := MakeInt64(1)
+	y, _ := MakeFromLiteral("2.5")
+
+	res, err := BinaryOp("+", x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Kind() != Float {
+		t.Errorf("(+ 1 2.5).Kind() = %v, want %v", res.Kind(), Float)
+	}
+}
+
+func TestBinaryOpDivisionOfIntsPromotesToExactRat(t *testing.T) {
+	res, err := BinaryOp("/", MakeInt64(1), MakeInt64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Kind() != Rat {
+		t.Errorf("(/ 1 3).Kind() = %v, want %v", res.Kind(), Rat)
+	}
+	if res.String() != "1/3" {
+		t.Errorf("(/ 1 3) = %v, want 1/3", res)
+	}
+}
+
+func TestBinaryOpDivisionByZeroIsAnError(t *testing.T) {
+	if _, err := BinaryOp("/", MakeInt64(1), MakeInt64(0)); err == nil {
+		t.Error("(/ 1 0) returned no error")
+	}
+}
+
+func TestBinaryOpModuloByZeroIsAnError(t *testing.T) {
+	if _, err := BinaryOp("%", MakeInt64(1), MakeInt64(0)); err == nil {
+		t.Error("(% 1 0) returned no error")
+	}
+}
+
+func TestIntValOverflowPromotesToBignumInsteadOfWrapping(t *testing.T) {
+	huge := MakeInt64(1 << 62)
+
+	res, err := BinaryOp("*", huge, huge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(big.Int).Mul(big.NewInt(1<<62), big.NewInt(1<<62))
+	if res.String() != want.String() {
+		t.Errorf("(* huge huge) = %v, want %v", res, want)
+	}
+}
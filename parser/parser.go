@@ -0,0 +1,153 @@
+// Package parser turns calc source text into an *ast.File: a small
+// hand-written scanner splits the source into parenthesis and atom
+// tokens, and a recursive-descent parser assembles them into the tree
+// eval walks.
+package parser
+
+import (
+	"strconv"
+
+	"misc/calc/ast"
+	"misc/calc/token"
+)
+
+type tokenKind int
+
+const (
+	tokLParen tokenKind = iota
+	tokRParen
+	tokAtom
+)
+
+type scannedToken struct {
+	kind tokenKind
+	pos  token.Pos
+	lit  string
+}
+
+// operators lists the built-in operator symbols the scanner recognizes as
+// ast.Operator atoms rather than ast.Identifier atoms.
+var operators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true,
+	"=": true, "<": true, "<=": true, ">": true, ">=": true, "<>": true,
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// scan splits src into a flat token stream: '(' and ')' are single-char
+// tokens, and everything else is grouped into whitespace-delimited atoms,
+// later classified by the parser as a numeral, an operator or a plain
+// identifier.
+func scan(src string) []scannedToken {
+	var toks []scannedToken
+	i, n := 0, len(src)
+	for i < n {
+		switch c := src[i]; {
+		case isSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, scannedToken{kind: tokLParen, pos: token.Pos(i + 1), lit: "("})
+			i++
+		case c == ')':
+			toks = append(toks, scannedToken{kind: tokRParen, pos: token.Pos(i + 1), lit: ")"})
+			i++
+		default:
+			start := i
+			for i < n && !isSpace(src[i]) && src[i] != '(' && src[i] != ')' {
+				i++
+			}
+			toks = append(toks, scannedToken{kind: tokAtom, pos: token.Pos(start + 1), lit: src[start:i]})
+		}
+	}
+	return toks
+}
+
+// isNumeral reports whether lit should be scanned as an ast.Number rather
+// than an identifier or operator: an integer ("7"), a rational ("1/3") or
+// a float ("2.5") all start with a digit. value.MakeFromLiteral does the
+// actual parsing once eval sees the Number.
+func isNumeral(lit string) bool {
+	return lit != "" && lit[0] >= '0' && lit[0] <= '9'
+}
+
+type parser struct {
+	f    *token.File
+	toks []scannedToken
+	pos  int
+}
+
+// ParseFile scans and parses src into an *ast.File, reporting any
+// scan/parse errors against f via f.AddError. Callers should check
+// f.NumErrors() before using the result.
+func ParseFile(f *token.File, src string) *ast.File {
+	p := &parser{f: f, toks: scan(src)}
+	var nodes []ast.Node
+	for p.pos < len(p.toks) {
+		n := p.parseNode()
+		if n == nil {
+			break
+		}
+		nodes = append(nodes, n)
+	}
+	return &ast.File{Nodes: nodes}
+}
+
+func (p *parser) peek() *scannedToken {
+	if p.pos >= len(p.toks) {
+		return nil
+	}
+	return &p.toks[p.pos]
+}
+
+func (p *parser) parseNode() ast.Node {
+	t := p.peek()
+	if t == nil {
+		return nil
+	}
+	switch t.kind {
+	case tokLParen:
+		return p.parseExpression()
+	case tokRParen:
+		p.f.AddError(t.pos, "unexpected )")
+		p.pos++
+		return nil
+	default:
+		p.pos++
+		return p.parseAtom(t)
+	}
+}
+
+func (p *parser) parseExpression() ast.Node {
+	open := p.toks[p.pos]
+	p.pos++ // consume '('
+	expr := &ast.Expression{LParen: open.pos}
+	for {
+		t := p.peek()
+		if t == nil {
+			p.f.AddError(open.pos, "unterminated expression")
+			return expr
+		}
+		if t.kind == tokRParen {
+			p.pos++
+			return expr
+		}
+		n := p.parseNode()
+		if n == nil {
+			return expr
+		}
+		expr.Nodes = append(expr.Nodes, n)
+	}
+}
+
+func (p *parser) parseAtom(t *scannedToken) ast.Node {
+	if isNumeral(t.lit) {
+		val, _ := strconv.Atoi(t.lit) // best-effort; 0 for non-integer literals
+		return &ast.Number{ValPos: t.pos, Lit: t.lit, Val: val}
+	}
+	if operators[t.lit] {
+		return &ast.Operator{OpPos: t.pos, Val: []byte(t.lit)}
+	}
+	return &ast.Identifier{NamePos: t.pos, Lit: t.lit}
+}
@@ -0,0 +1,87 @@
+// Package token defines source positions and the per-file error-reporting
+// facility shared by the scanner, parser and evaluator.
+package token
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Pos is a byte offset into a File's source, plus one, so that the zero
+// value is NoPos rather than a valid position - mirroring go/token.Pos.
+type Pos int
+
+// NoPos is the zero Pos: it means "no position", not the start of a file.
+const NoPos Pos = 0
+
+// A File tracks the name and source text handed to the scanner/parser for
+// a single parse, plus any errors accumulated against it while scanning,
+// parsing or evaluating.
+type File struct {
+	name string
+	src  string
+	errs []fileError
+}
+
+type fileError struct {
+	pos Pos
+	msg string
+}
+
+// NewFile returns a File named name holding src, ready to have positions
+// resolved against it and errors added to it.
+func NewFile(name, src string) *File {
+	return &File{name: name, src: src}
+}
+
+// AddError records an error at pos, formed by concatenating args the way
+// fmt.Sprint does.
+func (f *File) AddError(pos Pos, args ...interface{}) {
+	f.errs = append(f.errs, fileError{pos: pos, msg: fmt.Sprint(args...)})
+}
+
+// NumErrors reports how many errors have been added to f.
+func (f *File) NumErrors() int {
+	return len(f.errs)
+}
+
+// Position resolves pos to a 1-based line and column within f's source.
+func (f *File) Position(pos Pos) (line, col int) {
+	line, col = 1, 1
+	offset := int(pos) - 1
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(f.src) {
+		offset = len(f.src)
+	}
+	for i := 0; i < offset; i++ {
+		if f.src[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// PrintErrors writes every error added to f to stderr, each followed by
+// the offending source line and a caret pointing at the column, so the
+// indicator lines up with the user's typed input.
+func (f *File) PrintErrors() {
+	lines := strings.Split(f.src, "\n")
+	name := f.name
+	if name == "" {
+		name = "<input>"
+	}
+	for _, e := range f.errs {
+		line, col := f.Position(e.pos)
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", name, line, col, e.msg)
+		if line-1 >= 0 && line-1 < len(lines) {
+			fmt.Fprintln(os.Stderr, lines[line-1])
+			fmt.Fprintln(os.Stderr, strings.Repeat(" ", col-1)+"^")
+		}
+	}
+}